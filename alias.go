@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// loadAliases reads a user-supplied --alias-file mapping subtitle
+// filenames to video filenames, used to pair the leftovers automatic
+// matching couldn't. JSON files are read as a {"subtitle": "video"}
+// object; anything else is read as two-column CSV ("subtitle,video").
+func loadAliases(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading '%v': %w", path, err)
+	}
+
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		var aliases map[string]string
+		if err := json.Unmarshal(data, &aliases); err != nil {
+			return nil, fmt.Errorf("parsing '%v': %w", path, err)
+		}
+		return aliases, nil
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parsing '%v': %w", path, err)
+	}
+	aliases := map[string]string{}
+	for _, rec := range records {
+		if len(rec) < 2 {
+			continue
+		}
+		aliases[rec[0]] = rec[1]
+	}
+	return aliases, nil
+}
+
+// applyAliases pairs subtitle files in unmatchedSubs with video files in
+// unmatchedVideos according to aliases (subtitle basename -> video
+// basename), and returns what's still left over after that.
+func applyAliases(aliases map[string]string, unmatchedVideos, unmatchedSubs []string) (pairs map[string]string, stillUnmatchedVideos, stillUnmatchedSubs []string) {
+	videoByBase := map[string]string{}
+	for _, vf := range unmatchedVideos {
+		videoByBase[filepath.Base(vf)] = vf
+	}
+
+	pairs = map[string]string{}
+	vidUsed := map[string]bool{}
+	subUsed := map[string]bool{}
+	for _, sf := range unmatchedSubs {
+		vidName, ok := aliases[filepath.Base(sf)]
+		if !ok {
+			continue
+		}
+		vf, ok := videoByBase[vidName]
+		if !ok || vidUsed[vf] {
+			continue
+		}
+		pairs[vf] = sf
+		vidUsed[vf] = true
+		subUsed[sf] = true
+	}
+
+	for _, vf := range unmatchedVideos {
+		if !vidUsed[vf] {
+			stillUnmatchedVideos = append(stillUnmatchedVideos, vf)
+		}
+	}
+	for _, sf := range unmatchedSubs {
+		if !subUsed[sf] {
+			stillUnmatchedSubs = append(stillUnmatchedSubs, sf)
+		}
+	}
+	return pairs, stillUnmatchedVideos, stillUnmatchedSubs
+}
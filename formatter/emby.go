@@ -0,0 +1,16 @@
+package formatter
+
+// Emby names subtitles "<video>.<lang>.default.<subext>" so Emby's client
+// picks up the language and default-track flag from the filename alone
+// (e.g. "Show.S01E02.chinese.default.srt"). The video itself is renamed
+// the same way Plex does.
+type Emby struct{}
+
+func (Emby) Format(videoName, subName, lang string) (newVideo, newSubtitle string) {
+	newVideo = withoutExt(subName) + "." + ext(videoName)
+	base := withoutExt(videoName) + "." + ext(subName)
+	if lang != "" {
+		base = withoutExt(videoName) + "." + lang + ".default." + ext(subName)
+	}
+	return newVideo, base
+}
@@ -0,0 +1,54 @@
+// Package formatter computes output filenames for a matched video/subtitle
+// pair. Each Format encodes one naming convention (Plex, Emby, ...); new
+// conventions can be added without touching the main rename loop.
+package formatter
+
+import "strings"
+
+// Format produces the renamed video and subtitle filenames for a matched
+// pair. videoName and subName are base names (no directory component) -
+// the caller is responsible for rejoining whichever result it uses with
+// the directory of the file actually being renamed; lang is the language
+// tag to embed for formats that support it (may be empty).
+type Format interface {
+	Format(videoName, subName, lang string) (newVideo, newSubtitle string)
+}
+
+// Get returns the Format registered under name, and whether it was found.
+func Get(name string) (Format, bool) {
+	f, ok := formats[name]
+	return f, ok
+}
+
+// Names returns the names of all registered formats, for use in usage text.
+func Names() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	return names
+}
+
+var formats = map[string]Format{
+	"plex":          Plex{},
+	"emby":          Emby{},
+	"same-as-video": SameAsVideo{},
+}
+
+// ext returns the extension of name (without the leading '.').
+func ext(name string) string {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return ""
+	}
+	return name[i+1:]
+}
+
+// withoutExt returns name with its extension (if any) removed.
+func withoutExt(name string) string {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return name
+	}
+	return name[:i]
+}
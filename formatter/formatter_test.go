@@ -0,0 +1,83 @@
+package formatter
+
+import "testing"
+
+func TestExtAndWithoutExt(t *testing.T) {
+	tests := []struct {
+		name           string
+		in             string
+		wantExt        string
+		wantWithoutExt string
+	}{
+		{"simple extension", "Show.S01E02.mkv", "mkv", "Show.S01E02"},
+		{"no extension", "README", "", "README"},
+		{"multiple dots keeps all but the last", "Show.S01E02.en.srt", "srt", "Show.S01E02.en"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ext(tc.in); got != tc.wantExt {
+				t.Errorf("ext(%q) = %q, want %q", tc.in, got, tc.wantExt)
+			}
+			if got := withoutExt(tc.in); got != tc.wantWithoutExt {
+				t.Errorf("withoutExt(%q) = %q, want %q", tc.in, got, tc.wantWithoutExt)
+			}
+		})
+	}
+}
+
+func TestPlexFormat(t *testing.T) {
+	newVideo, newSubtitle := Plex{}.Format("Show.S01E02.XViD.mkv", "Show.S01E02.srt", "")
+	if want := "Show.S01E02.mkv"; newVideo != want {
+		t.Errorf("Plex newVideo = %q, want %q", newVideo, want)
+	}
+	if want := "Show.S01E02.XViD.srt"; newSubtitle != want {
+		t.Errorf("Plex newSubtitle = %q, want %q", newSubtitle, want)
+	}
+}
+
+func TestEmbyFormat(t *testing.T) {
+	tests := []struct {
+		name         string
+		lang         string
+		wantSubtitle string
+	}{
+		{"no lang falls back to Plex-style naming", "", "Show.S01E02.srt"},
+		{"lang embeds a default track tag", "chinese", "Show.S01E02.chinese.default.srt"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			newVideo, newSubtitle := Emby{}.Format("Show.S01E02.mkv", "Show.S01E02.srt", tc.lang)
+			if want := "Show.S01E02.mkv"; newVideo != want {
+				t.Errorf("Emby newVideo = %q, want %q", newVideo, want)
+			}
+			if newSubtitle != tc.wantSubtitle {
+				t.Errorf("Emby newSubtitle = %q, want %q", newSubtitle, tc.wantSubtitle)
+			}
+		})
+	}
+}
+
+func TestSameAsVideoFormat(t *testing.T) {
+	newVideo, newSubtitle := SameAsVideo{}.Format("Show.S01E02.mkv", "Show.S01E02.en.srt", "")
+	if want := "Show.S01E02.mkv"; newVideo != want {
+		t.Errorf("SameAsVideo newVideo = %q, want %q (video left untouched)", newVideo, want)
+	}
+	if want := "Show.S01E02.srt"; newSubtitle != want {
+		t.Errorf("SameAsVideo newSubtitle = %q, want %q", newSubtitle, want)
+	}
+}
+
+func TestGetAndNames(t *testing.T) {
+	if _, ok := Get("plex"); !ok {
+		t.Errorf("Get(\"plex\") not found")
+	}
+	if _, ok := Get("unknown"); ok {
+		t.Errorf("Get(\"unknown\") found, want not found")
+	}
+	names := Names()
+	if len(names) != 3 {
+		t.Errorf("Names() = %v, want 3 entries", names)
+	}
+}
@@ -0,0 +1,12 @@
+package formatter
+
+// Plex is the default naming convention: the subtitle takes the video's
+// extension, and vice versa, so the paired file shares the other's
+// basename (e.g. "Show.S01E02.srt" next to "Show.S01E02.mp4").
+type Plex struct{}
+
+func (Plex) Format(videoName, subName, lang string) (newVideo, newSubtitle string) {
+	newVideo = withoutExt(subName) + "." + ext(videoName)
+	newSubtitle = withoutExt(videoName) + "." + ext(subName)
+	return newVideo, newSubtitle
+}
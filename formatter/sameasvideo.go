@@ -0,0 +1,9 @@
+package formatter
+
+// SameAsVideo leaves the video untouched and has the subtitle take the
+// video's basename outright, with no other convention applied.
+type SameAsVideo struct{}
+
+func (SameAsVideo) Format(videoName, subName, lang string) (newVideo, newSubtitle string) {
+	return videoName, withoutExt(videoName) + "." + ext(subName)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+)
+
+// episodeKey holds every identifying field vsrename can extract from a
+// filename via named regex capture groups. Pairing tries these fields in
+// priority order (most to least specific): season+episode, absolute
+// episode number, then air date.
+type episodeKey struct {
+	Season   string
+	Episode  string
+	Absolute string
+	Date     string
+}
+
+// extractKey pulls an episodeKey out of name using re. If re doesn't name
+// any of "season", "episode", "absolute", or "date", its first capture
+// group is used as Episode, preserving the original single-group
+// behavior for regexes that don't care about the richer scheme.
+func extractKey(re *regexp.Regexp, name string) (episodeKey, bool) {
+	m := re.FindStringSubmatch(name)
+	if m == nil {
+		return episodeKey{}, false
+	}
+
+	var k episodeKey
+	named := false
+	for i, n := range re.SubexpNames() {
+		switch n {
+		case "season":
+			k.Season, named = m[i], true
+		case "episode":
+			k.Episode, named = m[i], true
+		case "absolute":
+			k.Absolute, named = m[i], true
+		case "date":
+			k.Date, named = m[i], true
+		}
+	}
+	if !named {
+		if len(m) < 2 {
+			return episodeKey{}, false
+		}
+		k.Episode = m[1]
+	}
+	return k, true
+}
+
+// pairingKeys lists k's lookup keys in priority order: season+episode,
+// then absolute episode number, then air date. A bare episode number is
+// only used as a key when none of the other three fields are present, so
+// it never shadows a more specific match.
+func pairingKeys(k episodeKey) []string {
+	var keys []string
+	if k.Season != "" && k.Episode != "" {
+		keys = append(keys, "se:"+k.Season+"|"+k.Episode)
+	}
+	if k.Absolute != "" {
+		keys = append(keys, "abs:"+k.Absolute)
+	}
+	if k.Date != "" {
+		keys = append(keys, "date:"+k.Date)
+	}
+	if k.Season == "" && k.Absolute == "" && k.Date == "" && k.Episode != "" {
+		keys = append(keys, "ep:"+k.Episode)
+	}
+	return keys
+}
+
+// pairFiles matches each video file in vidFiles to a subtitle file in
+// subFiles by trying, in priority order, every key pairingKeys produces
+// for it. subRegex/vidRegex are matched against each file's base name, not
+// its full path, so a parent directory like "Season 02" can't be mistaken
+// for part of the episode number. It returns the subtitle matched to each
+// paired video, and the video and subtitle files left unmatched
+// (candidates for --alias-file).
+func pairFiles(subRegex, vidRegex *regexp.Regexp, subFiles, vidFiles []string) (pairs map[string]string, unmatchedVideos, unmatchedSubs []string) {
+	pairs = map[string]string{}
+	subsByKey := map[string]string{}
+
+	var candidateSubs []string
+	for _, sf := range subFiles {
+		k, ok := extractKey(subRegex, filepath.Base(sf))
+		if !ok {
+			fmt.Printf("  [X] Ignoring subtitle file '%v' (does not match regex).\n", sf)
+			continue
+		}
+		candidateSubs = append(candidateSubs, sf)
+		for _, key := range pairingKeys(k) {
+			if _, exists := subsByKey[key]; !exists {
+				subsByKey[key] = sf
+			}
+		}
+	}
+
+	used := map[string]bool{}
+	for _, vf := range vidFiles {
+		k, ok := extractKey(vidRegex, filepath.Base(vf))
+		if !ok {
+			fmt.Printf("  [X] '%v' -> Skipping (episode not found matching regex).\n", vf)
+			continue
+		}
+		matched := false
+		for _, key := range pairingKeys(k) {
+			if sf, ok := subsByKey[key]; ok && !used[sf] {
+				pairs[vf] = sf
+				used[sf] = true
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			unmatchedVideos = append(unmatchedVideos, vf)
+		}
+	}
+
+	for _, sf := range candidateSubs {
+		if !used[sf] {
+			unmatchedSubs = append(unmatchedSubs, sf)
+		}
+	}
+
+	return pairs, unmatchedVideos, unmatchedSubs
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestExtractKey(t *testing.T) {
+	namedRe := regexp.MustCompile(`(?i)S(?P<season>\d{1,2})E(?P<episode>\d{1,3})`)
+	bareRe := regexp.MustCompile(`(\d{1,3})`)
+
+	tests := []struct {
+		name string
+		re   *regexp.Regexp
+		file string
+		want episodeKey
+		ok   bool
+	}{
+		{
+			name: "named groups populate the matching fields",
+			re:   namedRe,
+			file: "Show.S01E02.mkv",
+			want: episodeKey{Season: "01", Episode: "02"},
+			ok:   true,
+		},
+		{
+			name: "no match fails",
+			re:   namedRe,
+			file: "Show.mkv",
+			want: episodeKey{},
+			ok:   false,
+		},
+		{
+			name: "unnamed single group falls back to Episode",
+			re:   bareRe,
+			file: "Show 02.mkv",
+			want: episodeKey{Episode: "02"},
+			ok:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := extractKey(tc.re, tc.file)
+			if ok != tc.ok {
+				t.Fatalf("extractKey(%v) ok = %v, want %v", tc.file, ok, tc.ok)
+			}
+			if ok && got != tc.want {
+				t.Errorf("extractKey(%v) = %+v, want %+v", tc.file, got, tc.want)
+			}
+		})
+	}
+}
+
+// TestPairingKeysPriority checks that pairingKeys ranks keys from most to
+// least specific, and that a bare episode number is only ever produced
+// when none of season+episode, absolute, or date are present.
+func TestPairingKeysPriority(t *testing.T) {
+	tests := []struct {
+		name string
+		key  episodeKey
+		want []string
+	}{
+		{
+			name: "season+episode, absolute, and date all present, in priority order",
+			key:  episodeKey{Season: "1", Episode: "2", Absolute: "12", Date: "2020-01-02"},
+			want: []string{"se:1|2", "abs:12", "date:2020-01-02"},
+		},
+		{
+			name: "absolute and date, no season+episode",
+			key:  episodeKey{Absolute: "12", Date: "2020-01-02"},
+			want: []string{"abs:12", "date:2020-01-02"},
+		},
+		{
+			name: "bare episode only used when nothing else is present",
+			key:  episodeKey{Episode: "2"},
+			want: []string{"ep:2"},
+		},
+		{
+			name: "bare episode suppressed when season+episode is present",
+			key:  episodeKey{Season: "1", Episode: "2"},
+			want: []string{"se:1|2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := pairingKeys(tc.key)
+			if len(got) != len(tc.want) {
+				t.Fatalf("pairingKeys(%+v) = %v, want %v", tc.key, got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("pairingKeys(%+v)[%v] = %v, want %v", tc.key, i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestPairFilesPriority checks that a video matches the subtitle sharing
+// its most specific key, even when a looser key (e.g. absolute number)
+// would also match a different subtitle.
+func TestPairFilesPriority(t *testing.T) {
+	subRegex := regexp.MustCompile(`(?i)S(?P<season>\d{1,2})E(?P<episode>\d{1,3})|abs(?P<absolute>\d{1,3})`)
+	vidRegex := subRegex
+
+	subFiles := []string{"Show.S01E02.srt", "Show.abs002.srt"}
+	vidFiles := []string{"Show.S01E02.abs002.mkv"}
+
+	pairs, unmatchedVideos, unmatchedSubs := pairFiles(subRegex, vidRegex, subFiles, vidFiles)
+
+	want := "Show.S01E02.srt"
+	if got := pairs["Show.S01E02.abs002.mkv"]; got != want {
+		t.Errorf("pairs[video] = %v, want %v (season+episode should outrank absolute)", got, want)
+	}
+	if len(unmatchedVideos) != 0 {
+		t.Errorf("unmatchedVideos = %v, want none", unmatchedVideos)
+	}
+	if len(unmatchedSubs) != 1 || unmatchedSubs[0] != "Show.abs002.srt" {
+		t.Errorf("unmatchedSubs = %v, want [Show.abs002.srt]", unmatchedSubs)
+	}
+}
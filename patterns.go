@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// pattern is a built-in filename convention vsrename can recognize
+// automatically when no --subregex/--vidregex is supplied.
+type pattern struct {
+	name  string
+	regex *regexp.Regexp
+}
+
+// patterns lists the built-in conventions, in priority order: when
+// auto-detecting, a tie on match count is won by whichever pattern comes
+// first here.
+var patterns = []pattern{
+	{"s01e02", regexp.MustCompile(`(?i)S(?P<season>\d{1,2})E(?P<episode>\d{1,3})`)},
+	{"1x02", regexp.MustCompile(`(?P<season>\d{1,2})x(?P<episode>\d{1,3})`)},
+	{"dash", regexp.MustCompile(`-\s*(?P<episode>\d{1,3})\s*-`)},
+	{"ep02", regexp.MustCompile(`(?i)Ep\.?\s*(?P<episode>\d{1,3})`)},
+	{"folge", regexp.MustCompile(`(?i)Folge\s*(?P<episode>\d{1,3})`)},
+	{"bare", regexp.MustCompile(`(?P<episode>\d{1,4})`)},
+}
+
+// sanitizeName normalizes a filename ahead of matching by replacing the
+// punctuation shows are commonly delimited with (dots, underscores,
+// brackets) with spaces, so "Show.Name.-.02.-.720p" and "Show_Name_02"
+// both read as "Show Name 02".
+func sanitizeName(name string) string {
+	r := strings.NewReplacer(".", " ", "_", " ", "[", " ", "]", " ", "(", " ", ")", " ")
+	return r.Replace(name)
+}
+
+// findPreset looks up a built-in pattern by name, for --pattern-preset.
+func findPreset(name string) (pattern, bool) {
+	for _, p := range patterns {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return pattern{}, false
+}
+
+// detectPattern tries each built-in pattern (against a sanitized copy of
+// each name) and returns whichever matches the most names, along with how
+// many of names it matched.
+func detectPattern(names []string) (pattern, int) {
+	var best pattern
+	bestCount := -1
+	for _, p := range patterns {
+		count := 0
+		for _, n := range names {
+			if p.regex.MatchString(sanitizeName(n)) {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = p, count
+		}
+	}
+	return best, bestCount
+}
+
+// resolveRegex returns the regex to use for matching kind files ("subtitle"
+// or "video"): the user-supplied matcher if set, the forced presetName if
+// given, or else the best auto-detected built-in pattern across the files
+// found in byDir. flagName is the --subregex/--vidregex flag name, used in
+// the error message when neither a matcher nor a usable pattern is found.
+func resolveRegex(kind, flagName, matcher, presetName string, byDir map[string][]string) (*regexp.Regexp, error) {
+	if matcher != "" {
+		return regexp.MustCompile(matcher), nil
+	}
+
+	var names []string
+	for _, files := range byDir {
+		for _, f := range files {
+			names = append(names, filepath.Base(f))
+		}
+	}
+
+	if presetName != "" {
+		p, ok := findPreset(presetName)
+		if !ok {
+			return nil, fmt.Errorf("unknown --pattern-preset '%v'.", presetName)
+		}
+		fmt.Printf("Using '%v' pattern preset for %v files.\n", p.name, kind)
+		return p.regex, nil
+	}
+
+	best, count := detectPattern(names)
+	if count <= 0 {
+		return nil, fmt.Errorf("could not auto-detect an episode pattern for %v files; supply --%v explicitly.", kind, flagName)
+	}
+	fmt.Printf("Auto-detected '%v' pattern for %v files (%v/%v matched).\n", best.name, kind, count, len(names))
+	return best.regex, nil
+}
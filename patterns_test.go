@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+// TestDetectPatternTieBreak checks that when two built-in patterns match
+// the same number of filenames, detectPattern picks whichever comes
+// first in patterns (the more specific one), not whichever it happens to
+// see last.
+func TestDetectPatternTieBreak(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{
+			name:  "s01e02 beats bare on a tied count",
+			files: []string{"Show.S01E02.mkv", "Show.S01E03.mkv"},
+			want:  "s01e02",
+		},
+		{
+			name:  "1x02 beats bare on a tied count",
+			files: []string{"Show.1x02.mkv", "Show.2x03.mkv"},
+			want:  "1x02",
+		},
+		{
+			name:  "dash beats bare on a tied count",
+			files: []string{"Show - 01 -.mkv", "Show - 02 -.mkv"},
+			want:  "dash",
+		},
+		{
+			name:  "bare wins when nothing more specific matches",
+			files: []string{"Show 02.mkv", "Show 03.mkv"},
+			want:  "bare",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, count := detectPattern(tc.files)
+			if got.name != tc.want {
+				t.Errorf("detectPattern(%v) = %q (count %v), want %q", tc.files, got.name, count, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eugeneching/vsrename/formatter"
+)
+
+// planItem is one proposed rename: From the file as it exists on disk
+// right now, To what it will be renamed to.
+type planItem struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// buildPlanItem resolves what a matched video/subtitle pair renames to,
+// given the active format and target. detectedLang is used when --lang
+// wasn't supplied on the command line. The format is given vf/sf's base
+// names only, and its result is rejoined with the directory of whichever
+// file is actually being renamed - never the other file's directory, so
+// e.g. --flat pairing a video in one directory with a subtitle in
+// another renames the video in place instead of relocating it.
+func buildPlanItem(vf, sf string, format formatter.Format, detectedLang string) planItem {
+	effectiveLang := lang
+	if effectiveLang == "" {
+		effectiveLang = detectedLang
+	}
+	newVf, newSf := format.Format(filepath.Base(vf), filepath.Base(sf), effectiveLang)
+	if target == "subtitle" {
+		return planItem{From: sf, To: filepath.Join(filepath.Dir(sf), newSf)}
+	}
+	return planItem{From: vf, To: filepath.Join(filepath.Dir(vf), newVf)}
+}
+
+// resolvePlan applies conflictMode ("skip", "overwrite", or "suffix") to
+// plan items whose destination already exists on disk, and drops items
+// that are already a no-op. Two plan items renaming different sources to
+// the same destination is always an error: that ambiguity isn't something
+// any conflictMode can sanely resolve, so the whole run aborts instead.
+func resolvePlan(plan []planItem, conflictMode string) ([]planItem, error) {
+	destCount := map[string]int{}
+	for _, p := range plan {
+		destCount[p.To]++
+	}
+	for dest, count := range destCount {
+		if count > 1 {
+			return nil, fmt.Errorf("multiple files would be renamed to '%v'.", dest)
+		}
+	}
+
+	resolved := make([]planItem, 0, len(plan))
+	for _, p := range plan {
+		if p.From == p.To {
+			continue
+		}
+		if _, err := os.Stat(p.To); err == nil {
+			switch conflictMode {
+			case "skip":
+				fmt.Printf("  [X] '%v' -> Skipping ('%v' already exists).\n", p.From, p.To)
+				continue
+			case "suffix":
+				p.To = suffixedName(p.To)
+			case "overwrite":
+				// Leave p.To as-is; os.Rename will clobber it.
+			default:
+				return nil, fmt.Errorf("unknown --conflict '%v'.", conflictMode)
+			}
+		}
+		resolved = append(resolved, p)
+	}
+	return resolved, nil
+}
+
+// suffixedName finds a destination name that doesn't collide with an
+// existing file, by inserting " (n)" before the extension.
+func suffixedName(name string) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for n := 1; ; n++ {
+		candidate := fmt.Sprintf("%v (%v)%v", base, n, ext)
+		if _, err := os.Stat(candidate); err != nil {
+			return candidate
+		}
+	}
+}
+
+// printPlan prints the renames in plan as a preview, one per line.
+func printPlan(plan []planItem) {
+	for _, p := range plan {
+		fmt.Printf("  [*] '%v' -> '%v'\n", p.From, p.To)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolvePlanNoOpDropped(t *testing.T) {
+	plan := []planItem{{From: "a.mkv", To: "a.mkv"}}
+	resolved, err := resolvePlan(plan, "overwrite")
+	if err != nil {
+		t.Fatalf("resolvePlan() error = %v", err)
+	}
+	if len(resolved) != 0 {
+		t.Errorf("resolvePlan() = %v, want a no-op rename dropped", resolved)
+	}
+}
+
+func TestResolvePlanDestinationCollisionAborts(t *testing.T) {
+	plan := []planItem{
+		{From: "a.mkv", To: "c.mkv"},
+		{From: "b.mkv", To: "c.mkv"},
+	}
+	if _, err := resolvePlan(plan, "overwrite"); err == nil {
+		t.Errorf("resolvePlan() with two items renaming to the same destination returned nil error, want an error")
+	}
+}
+
+func TestResolvePlanConflictModes(t *testing.T) {
+	dir := t.TempDir()
+	from := filepath.Join(dir, "from.mkv")
+	to := filepath.Join(dir, "to.mkv")
+	if err := os.WriteFile(from, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(to, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("skip drops the item", func(t *testing.T) {
+		resolved, err := resolvePlan([]planItem{{From: from, To: to}}, "skip")
+		if err != nil {
+			t.Fatalf("resolvePlan() error = %v", err)
+		}
+		if len(resolved) != 0 {
+			t.Errorf("resolvePlan() with conflict=skip = %v, want the item dropped", resolved)
+		}
+	})
+
+	t.Run("overwrite keeps the original destination", func(t *testing.T) {
+		resolved, err := resolvePlan([]planItem{{From: from, To: to}}, "overwrite")
+		if err != nil {
+			t.Fatalf("resolvePlan() error = %v", err)
+		}
+		if len(resolved) != 1 || resolved[0].To != to {
+			t.Errorf("resolvePlan() with conflict=overwrite = %v, want To unchanged at %v", resolved, to)
+		}
+	})
+
+	t.Run("suffix picks a non-colliding destination", func(t *testing.T) {
+		resolved, err := resolvePlan([]planItem{{From: from, To: to}}, "suffix")
+		if err != nil {
+			t.Fatalf("resolvePlan() error = %v", err)
+		}
+		if len(resolved) != 1 || resolved[0].To == to {
+			t.Errorf("resolvePlan() with conflict=suffix = %v, want a destination distinct from %v", resolved, to)
+		}
+	})
+
+	t.Run("unknown conflict mode errors", func(t *testing.T) {
+		if _, err := resolvePlan([]planItem{{From: from, To: to}}, "bogus"); err == nil {
+			t.Errorf("resolvePlan() with an unknown --conflict returned nil error, want an error")
+		}
+	})
+}
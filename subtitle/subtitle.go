@@ -0,0 +1,155 @@
+// Package subtitle validates subtitle files and detects their encoding
+// and language before vsrename renames them into place.
+package subtitle
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/asticode/go-astisub"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Result carries what was learned about a subtitle file while validating
+// it: the language detected (suitable for embedding in a formatted
+// filename, e.g. "chinese"), whether its on-disk content was rewritten to
+// UTF-8, and whether it would be rewritten if write were true.
+type Result struct {
+	Lang               string
+	Converted          bool
+	NeedsNormalization bool
+}
+
+// Validate parses the subtitle file at path to make sure it's
+// well-formed, normalizing its encoding to UTF-8 on disk first when
+// normalizeEncoding and write are both true. When normalizeEncoding is
+// true but write is false, the rewrite is reported via
+// Result.NeedsNormalization instead of being performed, so a dry run
+// never touches the file. It returns the detected language, preferring
+// an ISO 639 tag found in the filename and falling back to a cue-text
+// heuristic.
+func Validate(path string, normalizeEncoding, write bool) (Result, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Result{}, fmt.Errorf("reading '%v': %w", path, err)
+	}
+
+	enc, converted := detectEncoding(raw)
+	if enc != nil {
+		utf8, err := enc.NewDecoder().Bytes(raw)
+		if err != nil {
+			return Result{}, fmt.Errorf("decoding '%v': %w", path, err)
+		}
+		if normalizeEncoding && converted && write {
+			if err := os.WriteFile(path, utf8, 0644); err != nil {
+				return Result{}, fmt.Errorf("normalizing encoding of '%v': %w", path, err)
+			}
+		}
+		raw = utf8
+	} else {
+		converted = false
+	}
+
+	subs, err := parse(path, raw)
+	if err != nil {
+		return Result{}, fmt.Errorf("parsing '%v': %w", path, err)
+	}
+	if len(subs.Items) == 0 {
+		return Result{}, fmt.Errorf("'%v' has no subtitle cues", path)
+	}
+
+	lang := langFromFilename(path)
+	if lang == "" {
+		lang = langFromCues(subs)
+	}
+
+	return Result{
+		Lang:               lang,
+		Converted:          normalizeEncoding && converted && write,
+		NeedsNormalization: normalizeEncoding && converted && !write,
+	}, nil
+}
+
+// parse dispatches to the astisub reader matching path's extension.
+func parse(path string, content []byte) (*astisub.Subtitles, error) {
+	r := bytes.NewReader(content)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".srt":
+		return astisub.ReadFromSRT(r)
+	case ".vtt":
+		return astisub.ReadFromWebVTT(r)
+	case ".ssa", ".ass":
+		return astisub.ReadFromSSA(r)
+	default:
+		return nil, astisub.ErrInvalidExtension
+	}
+}
+
+// detectEncoding sniffs raw for a byte-order mark and returns the
+// encoding.Encoding to decode it with, and whether it needs converting at
+// all (plain UTF-8, with or without a BOM, doesn't). A nil encoding means
+// raw is assumed to already be UTF-8.
+func detectEncoding(raw []byte) (enc encoding.Encoding, needsConversion bool) {
+	switch {
+	case bytes.HasPrefix(raw, []byte{0xEF, 0xBB, 0xBF}):
+		return unicode.UTF8, false
+	case bytes.HasPrefix(raw, []byte{0xFF, 0xFE}):
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM), true
+	case bytes.HasPrefix(raw, []byte{0xFE, 0xFF}):
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM), true
+	case !utf8.Valid(raw):
+		// No BOM and not valid UTF-8: assume the common subtitle fallback
+		// of Windows-1252.
+		return charmap.Windows1252, true
+	default:
+		return nil, false
+	}
+}
+
+// filenameLangs maps the ISO 639 tags and common names subtitle tools
+// tag filenames with to the name used in Emby-style output filenames.
+var filenameLangs = map[string]string{
+	"en": "english", "eng": "english", "english": "english",
+	"zh": "chinese", "chi": "chinese", "zho": "chinese", "chinese": "chinese",
+	"ja": "japanese", "jpn": "japanese", "japanese": "japanese",
+	"ko": "korean", "kor": "korean", "korean": "korean",
+	"fr": "french", "fre": "french", "fra": "french", "french": "french",
+	"de": "german", "ger": "german", "deu": "german", "german": "german",
+	"es": "spanish", "spa": "spanish", "spanish": "spanish",
+}
+
+var filenameLangRegex = regexp.MustCompile(`(?i)\.([a-z]{2,7})\.[a-z0-9]+$`)
+
+// langFromFilename looks for an ISO 639 tag in the penultimate
+// dot-delimited segment of path, e.g. "Show.S01E02.chinese.srt".
+func langFromFilename(path string) string {
+	m := filenameLangRegex.FindStringSubmatch(filepath.Base(path))
+	if m == nil {
+		return ""
+	}
+	return filenameLangs[strings.ToLower(m[1])]
+}
+
+// langFromCues applies a coarse cue-text heuristic: the presence of CJK
+// characters implies Chinese (Japanese subtitles are usually tagged in
+// the filename instead, so this is only a fallback), otherwise the
+// subtitle is assumed to be English, the common default.
+func langFromCues(subs *astisub.Subtitles) string {
+	for _, item := range subs.Items {
+		for _, line := range item.Lines {
+			for _, r := range line.String() {
+				if r >= 0x4E00 && r <= 0x9FFF {
+					return "chinese"
+				}
+			}
+		}
+	}
+	return "english"
+}
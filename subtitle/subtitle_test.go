@@ -0,0 +1,133 @@
+package subtitle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const validSRT = "1\n00:00:01,000 --> 00:00:02,000\nhello\n"
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name            string
+		raw             []byte
+		wantNil         bool
+		needsConversion bool
+	}{
+		{"UTF-8 BOM needs no conversion", []byte{0xEF, 0xBB, 0xBF, 'h', 'i'}, false, false},
+		{"UTF-16LE BOM needs conversion", []byte{0xFF, 0xFE, 'h', 0}, false, true},
+		{"UTF-16BE BOM needs conversion", []byte{0xFE, 0xFF, 0, 'h'}, false, true},
+		{"invalid UTF-8, no BOM, assumed Windows-1252", []byte{0xC7, 'a'}, false, true},
+		{"plain ASCII needs nothing", []byte("hello"), true, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			enc, needsConversion := detectEncoding(tc.raw)
+			if (enc == nil) != tc.wantNil {
+				t.Errorf("detectEncoding(%v) enc == nil = %v, want %v", tc.raw, enc == nil, tc.wantNil)
+			}
+			if needsConversion != tc.needsConversion {
+				t.Errorf("detectEncoding(%v) needsConversion = %v, want %v", tc.raw, needsConversion, tc.needsConversion)
+			}
+		})
+	}
+}
+
+func TestValidateDryRunNeverWrites(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Show.S01E02.srt")
+	raw := append([]byte{0xC7, 'a'}, []byte(" -> "+validSRT)...)
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Validate(path, true, false)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if res.Converted {
+		t.Errorf("Converted = true on a dry run (write=false), want false")
+	}
+	if !res.NeedsNormalization {
+		t.Errorf("NeedsNormalization = false, want true for content needing conversion with write=false")
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(raw) {
+		t.Errorf("Validate() with write=false modified the file on disk; got %q, want unchanged %q", got, raw)
+	}
+}
+
+func TestValidateWriteNormalizes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Show.S01E02.srt")
+	raw := append([]byte{0xC7, 'a'}, []byte(" -> "+validSRT)...)
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Validate(path, true, true)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if !res.Converted {
+		t.Errorf("Converted = false, want true when normalizeEncoding and write are both true")
+	}
+	if res.NeedsNormalization {
+		t.Errorf("NeedsNormalization = true after an actual write, want false")
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) == string(raw) {
+		t.Errorf("Validate() with write=true left the file byte-for-byte unchanged, want it rewritten to UTF-8")
+	}
+}
+
+func TestValidateLang(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		want     string
+	}{
+		{"ISO 639 tag in filename wins", "Show.S01E02.chinese.srt", validSRT, "chinese"},
+		{"CJK cue text falls back to chinese", "Show.S01E02.srt", "1\n00:00:01,000 --> 00:00:02,000\n你好\n", "chinese"},
+		{"no tag, no CJK cues defaults to english", "Show.S01E02.srt", validSRT, "english"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, tc.filename)
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			res, err := Validate(path, false, false)
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if res.Lang != tc.want {
+				t.Errorf("Validate(%v).Lang = %v, want %v", tc.filename, res.Lang, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateNoCues(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "empty.srt")
+	if err := os.WriteFile(path, []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Validate(path, false, false); err == nil {
+		t.Errorf("Validate() on an empty subtitle returned nil error, want an error about no cues")
+	}
+}
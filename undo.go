@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// undoJournalName is the file vsrename writes its rename journal to, in
+// the target directory, so a later --undo can reverse it.
+const undoJournalName = ".vsrename-undo.json"
+
+// executePlan performs every rename in plan, in order, and writes the
+// completed renames to a journal file in root so they can be undone
+// later. If a rename fails partway through, every rename done so far is
+// reversed and no journal is written.
+func executePlan(root string, plan []planItem) (int, error) {
+	done := make([]planItem, 0, len(plan))
+	for _, p := range plan {
+		if err := os.Rename(p.From, p.To); err != nil {
+			for i := len(done) - 1; i >= 0; i-- {
+				os.Rename(done[i].To, done[i].From)
+			}
+			return 0, fmt.Errorf("renaming '%v' to '%v': %w (rolled back)", p.From, p.To, err)
+		}
+		done = append(done, p)
+	}
+	if len(done) > 0 {
+		if err := writeJournal(root, done); err != nil {
+			return len(done), fmt.Errorf("renames succeeded but writing undo journal failed: %w", err)
+		}
+	}
+	return len(done), nil
+}
+
+// writeJournal records entries as root's undo journal, overwriting any
+// journal already there.
+func writeJournal(root string, entries []planItem) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(root, undoJournalName), data, 0644)
+}
+
+// undoLast reverses the renames recorded in root's journal, most recent
+// first, then removes the journal.
+func undoLast(root string) error {
+	path := filepath.Join(root, undoJournalName)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading '%v': %w", path, err)
+	}
+	var entries []planItem
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("parsing '%v': %w", path, err)
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		fmt.Printf("  [*] '%v' -> '%v'\n", e.To, e.From)
+		if err := os.Rename(e.To, e.From); err != nil {
+			return fmt.Errorf("undoing rename of '%v': %w", e.To, err)
+		}
+	}
+	return os.Remove(path)
+}
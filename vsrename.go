@@ -1,24 +1,99 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/asticode/go-astisub"
+
+	"github.com/eugeneching/vsrename/formatter"
+	"github.com/eugeneching/vsrename/subtitle"
 )
 
-// removeExt removes the extension, specified in argument ext, from a
-// filename. It returns the filename without the extension.
-func removeExt(filename, ext string) string {
-	return strings.TrimRight(filename, "."+ext)
+// walkFiles walks root looking for subtitle and video files, grouping them
+// by their containing directory. It descends into subdirectories only when
+// recursive is true, bounded by maxDepth levels (a negative maxDepth means
+// unlimited). Names matching the exclude glob (directories or files) are
+// skipped entirely.
+func walkFiles(root, subExt, vidExt string, recursive bool, maxDepth int, exclude string) (subsByDir, vidsByDir map[string][]string) {
+	subsByDir = map[string][]string{}
+	vidsByDir = map[string][]string{}
+
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if exclude != "" && path != root {
+			if ok, _ := filepath.Match(exclude, d.Name()); ok {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+			if !recursive {
+				return filepath.SkipDir
+			}
+			if maxDepth >= 0 {
+				rel, err := filepath.Rel(root, path)
+				if err == nil {
+					depth := strings.Count(filepath.Clean(rel), string(filepath.Separator)) + 1
+					if depth > maxDepth {
+						return filepath.SkipDir
+					}
+				}
+			}
+			return nil
+		}
+
+		dir := filepath.Dir(path)
+		switch strings.TrimPrefix(filepath.Ext(path), ".") {
+		case subExt:
+			subsByDir[dir] = append(subsByDir[dir], path)
+		case vidExt:
+			vidsByDir[dir] = append(vidsByDir[dir], path)
+		}
+		return nil
+	})
+
+	return subsByDir, vidsByDir
 }
 
-// addExt adds an extension, specified in argument ext, to a filename. It
-// returns the filename with the extension.
-func addExt(filenameWithoutExt, ext string) string {
-	return filenameWithoutExt + "." + ext
+// checkSubtitle validates sf before it's allowed into the pairing map. It
+// returns the detected language (empty if none could be determined), and
+// whether sf should still be paired. abort is set when --strict is given
+// and sf fails to parse, signalling that the whole run should stop.
+// --normalize-encoding only rewrites sf on disk when the run is also
+// actually writing (-w), so a dry run never mutates files; during a dry
+// run the would-be normalization is reported instead.
+func checkSubtitle(sf string) (lang string, valid, abort bool) {
+	res, err := subtitle.Validate(sf, normalizeEncoding, isWrite)
+	if errors.Is(err, astisub.ErrInvalidExtension) {
+		// Not a format vsrename knows how to parse (e.g. a custom
+		// --subext); pair it without validation or language detection.
+		return "", true, false
+	}
+	if err != nil {
+		fmt.Printf("  [X] Skipping subtitle '%v' (%v).\n", sf, err)
+		return "", false, strict
+	}
+	if res.Converted {
+		fmt.Printf("  [i] Normalized encoding of '%v' to UTF-8.\n", sf)
+	}
+	if res.NeedsNormalization {
+		fmt.Printf("  [i] '%v' would be normalized to UTF-8 (pass -w to perform).\n", sf)
+	}
+	return res.Lang, true, false
 }
 
 var (
@@ -28,6 +103,22 @@ var (
 	vidMatcher string
 	dir        string
 	isWrite    bool
+	formatName string
+	target     string
+	lang       string
+	recursive  bool
+	maxDepth   int
+	exclude    string
+	flatMode   bool
+	presetName string
+
+	normalizeEncoding bool
+	strict            bool
+
+	conflictMode string
+	undo         bool
+
+	aliasFile string
 )
 
 func init() {
@@ -38,7 +129,14 @@ func init() {
 		fmt.Printf("    [--subext=<subtitle extension>]\n")
 		fmt.Printf("    [--vidext=<video extension>]\n")
 		fmt.Printf("    [--subregex=<regex pattern to identify episode in subtitle files>]\n")
-		fmt.Printf("    [--vidregex=<regex pattern to identify episode in video files>]\n\n")
+		fmt.Printf("    [--vidregex=<regex pattern to identify episode in video files>]\n")
+		fmt.Printf("    (--subregex/--vidregex may be omitted; vsrename will auto-detect a pattern)\n")
+		fmt.Printf("    [--pattern-preset=<s01e02|1x02|dash|ep02|folge|bare>]\n")
+		fmt.Printf("    [--format=<plex|emby|same-as-video>] [--target=<video|subtitle>] [--lang=<language tag>]\n")
+		fmt.Printf("    [--recursive] [--max-depth=N] [--exclude=<glob>] [--flat]\n")
+		fmt.Printf("    [--normalize-encoding] [--strict]\n")
+		fmt.Printf("    [--conflict=<skip|overwrite|suffix>] [--undo]\n")
+		fmt.Printf("    [--alias-file=<path to CSV or JSON alias file>]\n\n")
 
 		fmt.Printf("  Examples:\n")
 		fmt.Printf("    (show renames without actually renaming)\n")
@@ -58,87 +156,193 @@ func init() {
 	flag.StringVar(&vidExt, "vidext", "mp4", "The extension of the video files (without leading '.', e.g. 'mp4')")
 	flag.StringVar(&vidMatcher, "vidregex", "", "The regex to identify episode of each video file (as a regex group)")
 
+	// Auto-detection.
+	flag.StringVar(&presetName, "pattern-preset", "", "Force a specific built-in pattern instead of auto-detecting one (s01e02, 1x02, dash, ep02, folge, bare); only used when --subregex/--vidregex is omitted")
+
 	// Paths.
 	flag.StringVar(&dir, "location", ".", "The path to the location of the video and subtitle files")
 	flag.StringVar(&dir, "l", ".", "The path to the location of the video and subtitle files")
 
+	// Walking.
+	flag.BoolVar(&recursive, "recursive", false, "Walk into subdirectories of --location")
+	flag.IntVar(&maxDepth, "max-depth", -1, "Maximum number of subdirectory levels to descend when --recursive is set (-1 for unlimited)")
+	flag.StringVar(&exclude, "exclude", "", "Glob pattern of file/directory names to skip during the walk")
+	flag.BoolVar(&flatMode, "flat", false, "Pool files from every matched directory and pair them across the whole tree, instead of matching within each directory independently")
+
 	// Commit the rename.
 	flag.BoolVar(&isWrite, "write", false, "Actually perform the rename")
 	flag.BoolVar(&isWrite, "w", false, "Actually perform the rename (shorthand)")
+
+	// Output naming.
+	flag.StringVar(&formatName, "format", "plex", "The output naming scheme to use (plex, emby, same-as-video)")
+	flag.StringVar(&target, "target", "video", "Which file to rename: 'video' (default) renames the video to match the subtitle, 'subtitle' renames the subtitle to match the video")
+	flag.StringVar(&lang, "lang", "", "The language tag to embed in the output filename (used by the emby format); overrides auto-detection")
+
+	// Validation.
+	flag.BoolVar(&normalizeEncoding, "normalize-encoding", false, "Rewrite subtitle files to UTF-8 on disk before renaming, if they aren't already")
+	flag.BoolVar(&strict, "strict", false, "Abort the whole run if any subtitle file fails to parse, instead of skipping it")
+
+	// Plan execution.
+	flag.StringVar(&conflictMode, "conflict", "overwrite", "What to do when a rename's destination already exists: 'skip', 'overwrite' (default), or 'suffix' it with ' (n)'")
+	flag.BoolVar(&undo, "undo", false, "Reverse the renames recorded in <location>/.vsrename-undo.json, then exit")
+
+	// Manual pairing.
+	flag.StringVar(&aliasFile, "alias-file", "", "CSV (subtitle,video) or JSON ({\"subtitle\": \"video\"}) file mapping subtitle filenames to video filenames, applied to whatever's left unmatched after automatic pairing")
 }
 
 func main() {
 	// Command line options.
 	flag.Parse()
 
-	// Regex (required arguments).
-	if subMatcher == "" || vidMatcher == "" {
-		fmt.Printf("Regex pattern for subtitle and videos required. Aborting.\n")
+	if undo {
+		if err := undoLast(dir); err != nil {
+			fmt.Printf("%v\n", err)
+			return
+		}
+		fmt.Printf("Undo complete.\n")
+		return
+	}
+
+	// Naming scheme.
+	format, ok := formatter.Get(formatName)
+	if !ok {
+		fmt.Printf("Unknown --format '%v' (valid: %v). Aborting.\n", formatName, formatter.Names())
+		return
+	}
+	if target != "video" && target != "subtitle" {
+		fmt.Printf("Unknown --target '%v' (valid: video, subtitle). Aborting.\n", target)
 		return
 	}
-	subRegex := regexp.MustCompile(subMatcher)
-	vidRegex := regexp.MustCompile(vidMatcher)
 
-	// Find subtitle and video files.
-	subFiles, _ := filepath.Glob(filepath.Join(dir, "*."+subExt))
-	vidFiles, _ := filepath.Glob(filepath.Join(dir, "*."+vidExt))
-	fmt.Printf("Found total %v video files (*.%v) and %v subtitle files (*.%v).\n", len(vidFiles), vidExt, len(subFiles), subExt)
-	if len(vidFiles) <= 0 {
+	// Find subtitle and video files, grouped by directory.
+	subsByDir, vidsByDir := walkFiles(dir, subExt, vidExt, recursive, maxDepth, exclude)
+	numSubFiles, numVidFiles := 0, 0
+	for _, files := range subsByDir {
+		numSubFiles += len(files)
+	}
+	for _, files := range vidsByDir {
+		numVidFiles += len(files)
+	}
+	fmt.Printf("Found total %v video files (*.%v) and %v subtitle files (*.%v).\n", numVidFiles, vidExt, numSubFiles, subExt)
+	if numVidFiles <= 0 {
 		fmt.Printf("No video files found. Aborting.\n")
 		return
 	}
 
-	// Store map of all subtitles.
-	subtitles := map[string]string{}
-	for _, sf := range subFiles {
-		// Get video episode.
-		m := (subRegex).FindStringSubmatch(sf)
-		if m == nil || len(m) < 2 {
-			fmt.Printf("  [X] Ignoring subtitle file '%v' (does not match regex).\n", sf)
-			continue
-		}
-		episode := m[1]
-		subtitles[episode] = sf
+	// Regex: explicit, forced preset, or auto-detected from the files found.
+	subRegex, err := resolveRegex("subtitle", "subregex", subMatcher, presetName, subsByDir)
+	if err != nil {
+		fmt.Printf("%v Aborting.\n", err)
+		return
 	}
-	if len(subtitles) <= 0 {
-		fmt.Printf("No subtitles matching regex found. Aborting.\n")
+	vidRegex, err := resolveRegex("video", "vidregex", vidMatcher, presetName, vidsByDir)
+	if err != nil {
+		fmt.Printf("%v Aborting.\n", err)
 		return
 	}
 
-	// Rename files.
-	numRenamed := 0
-	for _, vf := range vidFiles {
-		// Get video episode.
-		m := (vidRegex).FindStringSubmatch(vf)
-		if m == nil || len(m) < 2 {
-			fmt.Printf("  [X] '%v' -> Skipping (episode not found matching regex).\n", vf)
-			continue
+	// Match files (scoped per-directory, or pooled under --flat) and build
+	// the rename plan. subLangs is keyed by subtitle path, so it's shared
+	// across every scope.
+	var plan []planItem
+	var unmatchedVideos, unmatchedSubs []string
+	subLangs := map[string]string{}
+
+	pairGroup := func(subFiles, vidFiles []string) (abort bool) {
+		var validSubs []string
+		for _, sf := range subFiles {
+			subLang, valid, abort := checkSubtitle(sf)
+			if abort {
+				fmt.Printf("Aborting due to --strict.\n")
+				return true
+			}
+			if !valid {
+				continue
+			}
+			subLangs[sf] = subLang
+			validSubs = append(validSubs, sf)
+		}
+
+		pairs, leftoverVideos, leftoverSubs := pairFiles(subRegex, vidRegex, validSubs, vidFiles)
+		for vf, sf := range pairs {
+			plan = append(plan, buildPlanItem(vf, sf, format, subLangs[sf]))
+		}
+		unmatchedVideos = append(unmatchedVideos, leftoverVideos...)
+		unmatchedSubs = append(unmatchedSubs, leftoverSubs...)
+		return false
+	}
+
+	if flatMode {
+		var subFiles, vidFiles []string
+		for _, files := range subsByDir {
+			subFiles = append(subFiles, files...)
 		}
-		episode := m[1]
-
-		// Find matching subtitle file.
-		if sf, ok := subtitles[episode]; ok {
-			// Video file takes name of subtitle file (retaining video extension).
-			newVf := addExt(removeExt(sf, subExt), vidExt)
-			fmt.Printf("  [*] '%v' -> '%v'\n", vf, newVf)
-			if isWrite {
-				numRenamed += 1
-				os.Rename(vf, newVf)
+		for _, files := range vidsByDir {
+			vidFiles = append(vidFiles, files...)
+		}
+		if abort := pairGroup(subFiles, vidFiles); abort {
+			return
+		}
+	} else {
+		var dirs []string
+		for d := range vidsByDir {
+			dirs = append(dirs, d)
+		}
+		sort.Strings(dirs)
+
+		for _, d := range dirs {
+			if abort := pairGroup(subsByDir[d], vidsByDir[d]); abort {
+				return
 			}
-			continue
 		}
+	}
 
-		// Could not find matching subtitle file.
+	// Apply --alias-file to whatever's still left unmatched.
+	if aliasFile != "" {
+		aliases, err := loadAliases(aliasFile)
+		if err != nil {
+			fmt.Printf("%v Aborting.\n", err)
+			return
+		}
+		var aliasPairs map[string]string
+		aliasPairs, unmatchedVideos, unmatchedSubs = applyAliases(aliases, unmatchedVideos, unmatchedSubs)
+		for vf, sf := range aliasPairs {
+			plan = append(plan, buildPlanItem(vf, sf, format, subLangs[sf]))
+		}
+	}
+	for _, vf := range unmatchedVideos {
 		fmt.Printf("  [X] No subtitle file found for '%v'. Skipping.\n", vf)
 	}
 
-	// Show number of files renamed.
+	// Resolve destination conflicts, then preview the plan.
+	resolved, err := resolvePlan(plan, conflictMode)
+	if err != nil {
+		fmt.Printf("%v Aborting.\n", err)
+		return
+	}
+	printPlan(resolved)
 	fmt.Println()
+
+	if !isWrite {
+		switch len(resolved) {
+		case 0:
+			fmt.Printf("No files renamed.\n")
+		default:
+			fmt.Printf("%v files would be renamed (pass -w to perform).\n", len(resolved))
+		}
+		return
+	}
+
+	// Execute the plan and record it for --undo.
+	numRenamed, err := executePlan(dir, resolved)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return
+	}
 	switch numRenamed {
 	case 0:
 		fmt.Printf("No files renamed.\n")
 	default:
 		fmt.Printf("%v files renamed.\n", numRenamed)
-
 	}
-}
\ No newline at end of file
+}